@@ -2,69 +2,286 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/smtp"
+	"net/url"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
-	"gopkg.in/gomail.v2"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/hugohu0224/system-monitor/golang-alerthandler/notify"
+	"github.com/hugohu0224/system-monitor/golang-alerthandler/store"
 )
 
+// Alert is a single entry from an Alertmanager v4 webhook's alerts[] array.
 type Alert struct {
-	Status      string            `json:"status"`
-	Labels      map[string]string `json:"labels"`
-	Annotations map[string]string `json:"annotations"`
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     string            `json:"startsAt"`
+	EndsAt       string            `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
 }
 
+// AlertMessage is the full Alertmanager v4 webhook payload. Alertmanager
+// groups firing/resolved alerts server-side and sends one webhook call per
+// group, so one AlertMessage corresponds to one notification.
 type AlertMessage struct {
-	Alerts []Alert `json:"alerts"`
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	Receiver          string            `json:"receiver"`
+	Status            string            `json:"status"`
+	ExternalURL       string            `json:"externalURL"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+// alertTemplateData is the data made available to the subject/body
+// templates, combining the webhook payload with fields computed while
+// handling the alert.
+type alertTemplateData struct {
+	AlertMessage
+	SnapshotURL string
 }
 
+const defaultSubjectTemplate = `Monitor Alert [{{.Status}}]: {{index .CommonAnnotations "summary"}}`
+
+const defaultBodyTemplate = `<h1>[{{.Status}}] {{index .CommonAnnotations "summary"}}</h1>
+<p>{{index .CommonAnnotations "description"}}</p>
+{{range .Alerts}}
+<hr>
+<h3>{{.Status}}: {{index .Labels "alertname"}}</h3>
+<p>{{index .Annotations "description"}}</p>
+{{end}}
+<br><br>Grafana Snapshot: <a href='{{.SnapshotURL}}'>View Snapshot</a>`
+
 type Config struct {
-	GrafanaURL     string
-	GrafanaAPIKey  string
-	SMTPServer     string
-	SMTPPort       int
-	SenderEmail    string
-	SenderPassword string
-	RecipientEmail string
-	DashboardUID   string
-	Port           string
+	GrafanaURL        string
+	GrafanaAPIKey     string
+	DashboardUID      string
+	Port              string
+	RenderPanelIDs    []int
+	RenderWidth       int
+	RenderHeight      int
+	RenderTheme       string
+	RenderRangeMin    int
+	NotifyConfig      string
+	NotifyTimeoutMS   int
+	DashboardCacheTTL time.Duration
+	SubjectTemplate   string
+	BodyTemplate      string
+	QueueDBPath       string
+	SkipDiagnostics   bool
 }
 
 var cfg Config
+var notifyCfg *notify.Config
+var notifiers map[string]notify.Notifier
+var dashCache *dashboardCache
+var snapshotGroup singleflight.Group
+var subjectTmpl *template.Template
+var bodyTmpl *template.Template
+var alertStore *store.Store
+
+// registerConfigFlags binds every config field to a CLI flag, defaulting to
+// its environment variable so either source works. Unlike the old
+// getEnvOrFatal chain, nothing here exits on a missing value - that's left
+// to Config.Validate, which collects every problem before failing.
+func registerConfigFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&cfg.GrafanaURL, "grafana-url", getEnvOrDefault("GRAFANA_URL", ""), "Grafana base URL (env GRAFANA_URL)")
+	flags.StringVar(&cfg.GrafanaAPIKey, "grafana-api-key", getEnvOrDefault("GRAFANA_API_KEY", ""), "Grafana API token (env GRAFANA_API_KEY)")
+	flags.StringVar(&cfg.DashboardUID, "dashboard-uid", getEnvOrDefault("DASHBOARD_UID", ""), "Grafana dashboard UID to snapshot (env DASHBOARD_UID)")
+	flags.StringVar(&cfg.Port, "port", getEnvOrDefault("PORT", ""), "HTTP port to serve on (env PORT)")
+	flags.IntSliceVar(&cfg.RenderPanelIDs, "render-panel-ids", getEnvAsIntSlice("RENDER_PANEL_IDS", nil), "dashboard panel IDs to render inline (env RENDER_PANEL_IDS)")
+	flags.IntVar(&cfg.RenderWidth, "render-width", getEnvAsInt("RENDER_WIDTH", 1000), "rendered panel width in pixels (env RENDER_WIDTH)")
+	flags.IntVar(&cfg.RenderHeight, "render-height", getEnvAsInt("RENDER_HEIGHT", 500), "rendered panel height in pixels (env RENDER_HEIGHT)")
+	flags.StringVar(&cfg.RenderTheme, "render-theme", getEnvOrDefault("RENDER_THEME", "light"), "rendered panel theme (env RENDER_THEME)")
+	flags.IntVar(&cfg.RenderRangeMin, "render-range-minutes", getEnvAsInt("RENDER_RANGE_MINUTES", 30), "minutes padded on each side of the alert window when rendering (env RENDER_RANGE_MINUTES)")
+	flags.StringVar(&cfg.NotifyConfig, "notify-config", getEnvOrDefault("NOTIFY_CONFIG_FILE", "notify.yaml"), "path to the notifier routing config (env NOTIFY_CONFIG_FILE)")
+	flags.IntVar(&cfg.NotifyTimeoutMS, "notify-timeout-ms", getEnvAsInt("NOTIFY_TIMEOUT_MS", 10000), "per-notifier dispatch timeout in milliseconds (env NOTIFY_TIMEOUT_MS)")
+	flags.DurationVar(&cfg.DashboardCacheTTL, "dashboard-cache-ttl", time.Duration(getEnvAsInt("DASHBOARD_CACHE_TTL_SECONDS", 300))*time.Second, "how long cached dashboard JSON is trusted (env DASHBOARD_CACHE_TTL_SECONDS)")
+	flags.StringVar(&cfg.SubjectTemplate, "subject-template-file", getEnvOrDefault("SUBJECT_TEMPLATE_FILE", ""), "optional subject text/template file (env SUBJECT_TEMPLATE_FILE)")
+	flags.StringVar(&cfg.BodyTemplate, "body-template-file", getEnvOrDefault("BODY_TEMPLATE_FILE", ""), "optional body text/template file (env BODY_TEMPLATE_FILE)")
+	flags.StringVar(&cfg.QueueDBPath, "queue-db-path", getEnvOrDefault("QUEUE_DB_PATH", "alerts.db"), "BoltDB file backing the alert replay queue (env QUEUE_DB_PATH)")
+	flags.BoolVar(&cfg.SkipDiagnostics, "skip-diagnostics", getEnvAsBool("SKIP_DIAGNOSTICS", false), "skip startup diagnostics (Grafana/SMTP reachability checks) (env SKIP_DIAGNOSTICS)")
+}
 
-func init() {
-	log.Println("Initializing alert handler...")
-	cfg = loadConfig()
-	log.Printf("Configuration: GRAFANA_URL=%s, SMTP_SERVER=%s, SENDER_EMAIL=%s, RECIPIENT_EMAIL=%s, SMTP_PORT=%d",
-		cfg.GrafanaURL, cfg.SMTPServer, cfg.SenderEmail, cfg.RecipientEmail, cfg.SMTPPort)
+// Validate collects every missing or invalid field instead of failing on
+// the first one, so a misconfigured deployment can be fixed in one pass
+// rather than one `log.Fatalf` at a time.
+func (c Config) Validate() error {
+	var problems []string
+
+	if c.GrafanaURL == "" {
+		problems = append(problems, "--grafana-url (env GRAFANA_URL) is required")
+	}
+	if c.GrafanaAPIKey == "" {
+		problems = append(problems, "--grafana-api-key (env GRAFANA_API_KEY) is required")
+	}
+	if c.DashboardUID == "" {
+		problems = append(problems, "--dashboard-uid (env DASHBOARD_UID) is required")
+	}
+	if c.Port == "" {
+		problems = append(problems, "--port (env PORT) is required")
+	}
+	if c.NotifyConfig == "" {
+		problems = append(problems, "--notify-config (env NOTIFY_CONFIG_FILE) is required")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// runDiagnostics probes every external dependency the handler needs at
+// startup - Grafana, the configured dashboard, and any SMTP notifier -
+// collecting every failure instead of waiting for the first alert to
+// discover a misconfiguration at 3am.
+func runDiagnostics() error {
+	var problems []string
+
+	if err := probeGrafana(); err != nil {
+		problems = append(problems, err.Error())
+	} else if err := probeDashboard(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	for _, nc := range notifyCfg.Notifiers {
+		if nc.Type != "smtp" {
+			continue
+		}
+		if err := probeSMTP(nc.SMTPServer, nc.SMTPPort); err != nil {
+			problems = append(problems, fmt.Sprintf("smtp notifier %q: %v", nc.Name, err))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("startup diagnostics failed:\n  - %s", strings.Join(problems, "\n  - "))
+}
+
+// probeGrafana checks that GrafanaURL/GrafanaAPIKey actually reach a healthy
+// Grafana instance, rather than failing on the first real alert.
+func probeGrafana() error {
+	healthURL := fmt.Sprintf("%s/api/health", cfg.GrafanaURL)
+	req, err := http.NewRequest("GET", healthURL, nil)
+	if err != nil {
+		return fmt.Errorf("grafana health check: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.GrafanaAPIKey)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("grafana health check: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("grafana health check: unexpected status code %d", resp.StatusCode)
+	}
+	return nil
 }
 
-func loadConfig() Config {
-	return Config{
-		GrafanaURL:     getEnvOrFatal("GRAFANA_URL"),
-		GrafanaAPIKey:  getEnvOrFatal("GRAFANA_API_KEY"),
-		SMTPServer:     getEnvOrFatal("SMTP_SERVER"),
-		SenderEmail:    getEnvOrFatal("SENDER_EMAIL"),
-		SenderPassword: getEnvOrFatal("SENDER_PASSWORD"),
-		RecipientEmail: getEnvOrFatal("RECIPIENT_EMAIL"),
-		SMTPPort:       getEnvAsInt("SMTP_PORT", 587),
-		DashboardUID:   getEnvOrFatal("DASHBOARD_UID"),
-		Port:           getEnvOrFatal("PORT"),
+// probeDashboard verifies DashboardUID resolves to a real dashboard, since a
+// typo there would otherwise only surface once the first alert tries to
+// snapshot it.
+func probeDashboard() error {
+	if _, err := getDashboardVersion(cfg.DashboardUID); err != nil {
+		return fmt.Errorf("dashboard %q: %w", cfg.DashboardUID, err)
 	}
+	return nil
+}
+
+// probeSMTP dials the SMTP server and, when the server advertises STARTTLS,
+// runs the STARTTLS handshake, all without sending any mail. A server that
+// does not advertise STARTTLS passes on reachability alone.
+func probeSMTP(server string, port int) error {
+	addr := fmt.Sprintf("%s:%d", server, port)
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("dial %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	client, err := smtp.NewClient(conn, server)
+	if err != nil {
+		return fmt.Errorf("smtp handshake with %s: %w", addr, err)
+	}
+	defer client.Quit()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: server}); err != nil {
+			return fmt.Errorf("starttls with %s: %w", addr, err)
+		}
+	}
+	return nil
+}
+
+// loadTemplate parses the template at file, falling back to fallback when
+// file is unset, letting operators customize notification text without a
+// code change.
+func loadTemplate(name, file, fallback string) (*template.Template, error) {
+	body := fallback
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s template %s: %w", name, file, err)
+		}
+		body = string(data)
+	}
+
+	tmpl, err := template.New(name).Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+func getEnvOrDefault(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
 }
 
-func getEnvOrFatal(key string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		log.Fatalf("Missing required environment variable: %s", key)
+func getEnvAsIntSlice(key string, fallback []int) []int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
 	}
-	return value
+
+	var result []int
+	for _, part := range strings.Split(value, ",") {
+		intValue, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			log.Printf("error converting %s entry %q to int, skipping", key, part)
+			continue
+		}
+		result = append(result, intValue)
+	}
+	return result
 }
 
 func getEnvAsInt(key string, fallback int) int {
@@ -77,21 +294,44 @@ func getEnvAsInt(key string, fallback int) int {
 	return fallback
 }
 
-func createGrafanaSnapshot(dashboardUID string) (string, error) {
-	dashboard, err := getDashboardConfig(dashboardUID)
-	if err != nil {
-		return "", fmt.Errorf("error getting dashboard config: %w", err)
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, ok := os.LookupEnv(key); ok {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		log.Printf("error converting %s to bool, using fallback value", key)
 	}
+	return fallback
+}
+
+// createGrafanaSnapshot is coalesced per dashboardUID with singleflight so a
+// burst of alerts referencing the same dashboard only creates one snapshot.
+func createGrafanaSnapshot(dashboardUID string) (string, error) {
+	result, err, _ := snapshotGroup.Do(dashboardUID, func() (interface{}, error) {
+		dashboard, err := getDashboardConfig(dashboardUID)
+		if err != nil {
+			return "", fmt.Errorf("error getting dashboard config: %w", err)
+		}
 
-	snapshotURL, err := createSnapshot(dashboard)
+		snapshotURL, err := createSnapshot(dashboard)
+		if err != nil {
+			return "", fmt.Errorf("error creating snapshot: %w", err)
+		}
+
+		return snapshotURL, nil
+	})
 	if err != nil {
-		return "", fmt.Errorf("error creating snapshot: %w", err)
+		return "", err
 	}
 
-	return snapshotURL, nil
+	return result.(string), nil
 }
 
 func getDashboardConfig(dashboardUID string) (map[string]interface{}, error) {
+	if dashboard, ok := dashCache.get(dashboardUID); ok {
+		return dashboard, nil
+	}
+
 	dashboardURL := fmt.Sprintf("%s/api/dashboards/uid/%s", cfg.GrafanaURL, dashboardUID)
 	resp, err := sendRequest("GET", dashboardURL, nil)
 	if err != nil {
@@ -109,9 +349,70 @@ func getDashboardConfig(dashboardUID string) (map[string]interface{}, error) {
 		return nil, fmt.Errorf("dashboard data not found in response")
 	}
 
+	dashCache.set(dashboardUID, dashboard, resp.Header.Get("ETag"))
+
 	return dashboard, nil
 }
 
+// getDashboardVersion is used once at startup (probeDashboard) to confirm
+// DashboardUID resolves to a real dashboard. It is not on the per-alert
+// path: dashboardCache.checkFresh below is what storms of alerts hit.
+func getDashboardVersion(dashboardUID string) (int, error) {
+	dashboardURL := fmt.Sprintf("%s/api/dashboards/uid/%s", cfg.GrafanaURL, dashboardUID)
+	resp, err := sendRequest("GET", dashboardURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var versionResp struct {
+		Dashboard struct {
+			Version int `json:"version"`
+		} `json:"dashboard"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&versionResp); err != nil {
+		return 0, fmt.Errorf("error decoding dashboard version response: %w", err)
+	}
+
+	return versionResp.Dashboard.Version, nil
+}
+
+// checkDashboardFresh asks Grafana whether dashboardUID has changed since
+// etag was captured, using a conditional GET (If-None-Match). A match gets
+// back a bodyless 304, so a storm of alerts hitting an unchanged dashboard
+// costs one small request each instead of a full dashboard re-fetch. An
+// empty etag (nothing cached yet) is always reported stale.
+func checkDashboardFresh(dashboardUID, etag string) (bool, error) {
+	if etag == "" {
+		return false, nil
+	}
+
+	dashboardURL := fmt.Sprintf("%s/api/dashboards/uid/%s", cfg.GrafanaURL, dashboardUID)
+	req, err := http.NewRequest("GET", dashboardURL, nil)
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.GrafanaAPIKey)
+	req.Header.Set("If-None-Match", etag)
+
+	client := &http.Client{Timeout: time.Second * 10}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error checking dashboard freshness: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return true, nil
+	case http.StatusOK:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status code checking dashboard freshness: %d", resp.StatusCode)
+	}
+}
+
 func createSnapshot(dashboard map[string]interface{}) (string, error) {
 	snapshotURL := fmt.Sprintf("%s/api/snapshots", cfg.GrafanaURL)
 	payload := map[string]interface{}{
@@ -143,6 +444,192 @@ func createSnapshot(dashboard map[string]interface{}) (string, error) {
 	return snapshotURL, nil
 }
 
+// dashboardCacheEntry holds a gzip-compressed dashboard JSON payload along
+// with the ETag it was captured under, so staleness can be checked with a
+// cheap conditional request instead of re-fetching and re-decoding the
+// full body.
+type dashboardCacheEntry struct {
+	gzippedJSON []byte
+	etag        string
+	cachedAt    time.Time
+}
+
+// dashboardCache is a TTL cache of dashboard JSON keyed by DashboardUID,
+// compressed in memory to keep storms of identical alerts cheap. checkFresh
+// is a field rather than a direct call to checkDashboardFresh so tests can
+// stub out the network round trip.
+type dashboardCache struct {
+	mu         sync.RWMutex
+	ttl        time.Duration
+	entries    map[string]dashboardCacheEntry
+	checkFresh func(dashboardUID, etag string) (bool, error)
+}
+
+func newDashboardCache(ttl time.Duration) *dashboardCache {
+	return &dashboardCache{ttl: ttl, entries: make(map[string]dashboardCacheEntry), checkFresh: checkDashboardFresh}
+}
+
+func (c *dashboardCache) get(dashboardUID string) (map[string]interface{}, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[dashboardUID]
+	c.mu.RUnlock()
+	if !ok || time.Since(entry.cachedAt) > c.ttl {
+		return nil, false
+	}
+
+	fresh, err := c.checkFresh(dashboardUID, entry.etag)
+	if err != nil {
+		log.Printf("error checking dashboard %s freshness, bypassing cache: %v", dashboardUID, err)
+		return nil, false
+	}
+	if !fresh {
+		return nil, false
+	}
+
+	raw, err := gunzipBytes(entry.gzippedJSON)
+	if err != nil {
+		log.Printf("error decompressing cached dashboard %s: %v", dashboardUID, err)
+		return nil, false
+	}
+
+	var dashboard map[string]interface{}
+	if err := json.Unmarshal(raw, &dashboard); err != nil {
+		log.Printf("error decoding cached dashboard %s: %v", dashboardUID, err)
+		return nil, false
+	}
+
+	return dashboard, true
+}
+
+func (c *dashboardCache) set(dashboardUID string, dashboard map[string]interface{}, etag string) {
+	raw, err := json.Marshal(dashboard)
+	if err != nil {
+		log.Printf("error marshalling dashboard %s for cache: %v", dashboardUID, err)
+		return
+	}
+
+	gzipped, err := gzipBytes(raw)
+	if err != nil {
+		log.Printf("error compressing dashboard %s for cache: %v", dashboardUID, err)
+		return
+	}
+
+	c.mu.Lock()
+	c.entries[dashboardUID] = dashboardCacheEntry{gzippedJSON: gzipped, etag: etag, cachedAt: time.Now()}
+	c.mu.Unlock()
+}
+
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(data []byte) ([]byte, error) {
+	gr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	return io.ReadAll(gr)
+}
+
+// panelSnapshot is a rendered PNG for one dashboard panel, ready to be
+// embedded inline in an email via its Content-ID.
+type panelSnapshot struct {
+	PanelID int
+	CID     string
+	PNG     []byte
+}
+
+func renderPanelSnapshots(dashboardUID string, from, to time.Time, groupLabels map[string]string) []panelSnapshot {
+	if len(cfg.RenderPanelIDs) == 0 {
+		return nil
+	}
+
+	theme := cfg.RenderTheme
+	if override, ok := groupLabels["render_theme"]; ok && override != "" {
+		theme = override
+	}
+
+	var snapshots []panelSnapshot
+	for _, panelID := range cfg.RenderPanelIDs {
+		png, err := renderPanelPNG(dashboardUID, panelID, from, to, theme)
+		if err != nil {
+			log.Printf("error rendering panel %d: %v", panelID, err)
+			continue
+		}
+		snapshots = append(snapshots, panelSnapshot{
+			PanelID: panelID,
+			CID:     fmt.Sprintf("panel-%d.png", panelID),
+			PNG:     png,
+		})
+	}
+	return snapshots
+}
+
+// renderTimeRangeForGroup computes the render window spanning every alert in
+// the group, from the earliest StartsAt to the latest EndsAt (or now, for
+// alerts still firing), padded by RenderRangeMin on each side.
+func renderTimeRangeForGroup(alerts []Alert) (time.Time, time.Time) {
+	now := time.Now()
+	var start, end time.Time
+
+	for _, alert := range alerts {
+		if parsed, err := time.Parse(time.RFC3339, alert.StartsAt); err == nil {
+			if start.IsZero() || parsed.Before(start) {
+				start = parsed
+			}
+		}
+		if parsed, err := time.Parse(time.RFC3339, alert.EndsAt); err == nil && !parsed.IsZero() {
+			if end.IsZero() || parsed.After(end) {
+				end = parsed
+			}
+		}
+	}
+
+	if start.IsZero() {
+		start = now
+	}
+	if end.IsZero() {
+		end = now
+	}
+
+	half := time.Duration(cfg.RenderRangeMin) * time.Minute
+	return start.Add(-half), end.Add(half)
+}
+
+func renderPanelPNG(dashboardUID string, panelID int, from, to time.Time, theme string) ([]byte, error) {
+	query := url.Values{}
+	query.Set("panelId", strconv.Itoa(panelID))
+	query.Set("width", strconv.Itoa(cfg.RenderWidth))
+	query.Set("height", strconv.Itoa(cfg.RenderHeight))
+	query.Set("theme", theme)
+	query.Set("from", strconv.FormatInt(from.UnixMilli(), 10))
+	query.Set("to", strconv.FormatInt(to.UnixMilli(), 10))
+
+	renderURL := fmt.Sprintf("%s/render/d-solo/%s?%s", cfg.GrafanaURL, url.PathEscape(dashboardUID), query.Encode())
+
+	resp, err := sendRequest("GET", renderURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering panel %d: %w", panelID, err)
+	}
+	defer resp.Body.Close()
+
+	png, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rendered panel %d: %w", panelID, err)
+	}
+
+	return png, nil
+}
+
 func sendRequest(method, url string, body io.Reader) (*http.Response, error) {
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
@@ -168,16 +655,79 @@ func sendRequest(method, url string, body io.Reader) (*http.Response, error) {
 	return resp, nil
 }
 
-func sendEmailWithSnapshotLink(subject, body, snapshotURL string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", cfg.SenderEmail)
-	m.SetHeader("To", cfg.RecipientEmail)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", fmt.Sprintf("%s<br><br>Grafana Snapshot: <a href='%s'>View Snapshot</a>", body, snapshotURL))
+func toNotifyAlertMessage(alertMessage AlertMessage) notify.Alert {
+	fingerprints := make([]string, 0, len(alertMessage.Alerts))
+	for _, alert := range alertMessage.Alerts {
+		fingerprints = append(fingerprints, alert.Fingerprint)
+	}
+	sort.Strings(fingerprints)
+
+	return notify.Alert{
+		Status:       alertMessage.Status,
+		Labels:       alertMessage.CommonLabels,
+		Annotations:  alertMessage.CommonAnnotations,
+		GroupKey:     alertMessage.GroupKey,
+		Fingerprints: fingerprints,
+	}
+}
+
+func renderTemplate(tmpl *template.Template, data alertTemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func toAttachments(panels []panelSnapshot) []notify.Attachment {
+	attachments := make([]notify.Attachment, 0, len(panels))
+	for _, panel := range panels {
+		attachments = append(attachments, notify.Attachment{
+			Name:        panel.CID,
+			ContentType: "image/png",
+			Data:        panel.PNG,
+		})
+	}
+	return attachments
+}
+
+// processAlertMessage runs the full snapshot/render/template/dispatch
+// pipeline for one alert group. It's shared by the live webhook handler and
+// the replay path so a replayed alert is indistinguishable from a fresh one.
+func processAlertMessage(ctx context.Context, alertMessage AlertMessage) error {
+	log.Printf("processing alert group %s (status=%s, receiver=%s) with %d alerts",
+		alertMessage.GroupKey, alertMessage.Status, alertMessage.Receiver, len(alertMessage.Alerts))
+
+	snapshotURL, err := createGrafanaSnapshot(cfg.DashboardUID)
+	if err != nil {
+		log.Printf("error creating Grafana snapshot: %v", err)
+		snapshotURL = "failed to get snapshot URL"
+	}
+
+	from, to := renderTimeRangeForGroup(alertMessage.Alerts)
+	panels := renderPanelSnapshots(cfg.DashboardUID, from, to, alertMessage.CommonLabels)
+
+	data := alertTemplateData{AlertMessage: alertMessage, SnapshotURL: snapshotURL}
 
-	d := gomail.NewDialer(cfg.SMTPServer, cfg.SMTPPort, cfg.SenderEmail, cfg.SenderPassword)
-	if err := d.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+	subject, err := renderTemplate(subjectTmpl, data)
+	if err != nil {
+		return fmt.Errorf("error rendering subject template: %w", err)
+	}
+
+	emailBody, err := renderTemplate(bodyTmpl, data)
+	if err != nil {
+		return fmt.Errorf("error rendering body template: %w", err)
+	}
+
+	selected := notifyCfg.Select(notifiers, alertMessage.CommonLabels)
+	if len(selected) == 0 {
+		log.Printf("no notifiers matched alert group %s, skipping", alertMessage.GroupKey)
+		return nil
+	}
+
+	timeout := time.Duration(cfg.NotifyTimeoutMS) * time.Millisecond
+	if err := notify.Dispatch(ctx, selected, timeout, toNotifyAlertMessage(alertMessage), subject, emailBody, toAttachments(panels)); err != nil {
+		return fmt.Errorf("error dispatching notifications: %w", err)
 	}
 
 	return nil
@@ -200,30 +750,235 @@ func handleAlert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for _, alert := range alertMessage.Alerts {
-		log.Printf("received alert: %s", alert.Annotations["summary"])
+	id, err := alertStore.Enqueue(body)
+	if err != nil {
+		log.Printf("error persisting alert: %v", err)
+	}
 
-		snapshotURL, err := createGrafanaSnapshot(cfg.DashboardUID)
-		if err != nil {
-			log.Printf("error creating Grafana snapshot: %v", err)
-			snapshotURL = "failed to get snapshot URL"
+	if err := processAlertMessage(r.Context(), alertMessage); err != nil {
+		log.Printf("error processing alert group %s: %v", alertMessage.GroupKey, err)
+		if id != "" {
+			_ = alertStore.MarkFailed(id, err)
 		}
+		http.Error(w, "error dispatching notifications", http.StatusInternalServerError)
+		return
+	}
 
-		subject := fmt.Sprintf("Monitor Alert: %s", alert.Annotations["summary"])
-		body := fmt.Sprintf("<h1>%s</h1><p>%s</p>", alert.Annotations["summary"], alert.Annotations["description"])
-
-		if err := sendEmailWithSnapshotLink(subject, body, snapshotURL); err != nil {
-			log.Printf("error sending email: %v", err)
-			http.Error(w, "Eerror sending email", http.StatusInternalServerError)
-			return
-		}
+	if id != "" {
+		_ = alertStore.MarkSent(id)
 	}
+
 	w.WriteHeader(http.StatusOK)
 	log.Println("alert processing completed")
 }
 
-func main() {
+// handleAlertHistory returns recently received alerts and their dispatch
+// status.
+func handleAlertHistory(w http.ResponseWriter, r *http.Request) {
+	records, err := alertStore.List(100)
+	if err != nil {
+		log.Printf("error listing alert history: %v", err)
+		http.Error(w, "error listing alert history", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		log.Printf("error encoding alert history: %v", err)
+	}
+}
+
+// handleAlertReplay manually re-triggers dispatch for a single stored alert.
+func handleAlertReplay(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/alerts/replay/")
+	if id == "" {
+		http.Error(w, "missing alert id", http.StatusBadRequest)
+		return
+	}
+
+	record, err := alertStore.Get(id)
+	if err != nil {
+		http.Error(w, "alert not found", http.StatusNotFound)
+		return
+	}
+
+	var alertMessage AlertMessage
+	if err := json.Unmarshal(record.Payload, &alertMessage); err != nil {
+		http.Error(w, "error decoding stored alert", http.StatusInternalServerError)
+		return
+	}
+
+	if err := processAlertMessage(r.Context(), alertMessage); err != nil {
+		log.Printf("error replaying alert %s: %v", id, err)
+		_ = alertStore.MarkFailed(id, err)
+		http.Error(w, "error replaying alert", http.StatusInternalServerError)
+		return
+	}
+
+	_ = alertStore.MarkSent(id)
+	w.WriteHeader(http.StatusOK)
+}
+
+func runServer() {
 	http.HandleFunc("/alert", handleAlert)
+	http.HandleFunc("/alerts/history", handleAlertHistory)
+	http.HandleFunc("/alerts/replay/", handleAlertReplay)
 	log.Printf("Starting server on port %s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, nil))
 }
+
+// runReplay re-processes alerts from the persistent queue: by default every
+// unacked (not yet successfully sent) alert, optionally filtered to a
+// [since, until) receive-time window, or every stored alert when all is set.
+func runReplay(since, until string, all bool) {
+	records, err := alertStore.Unacked()
+	if err != nil {
+		log.Fatalf("error listing unacked alerts: %v", err)
+	}
+	if all {
+		records, err = alertStore.List(0)
+		if err != nil {
+			log.Fatalf("error listing alerts: %v", err)
+		}
+	}
+
+	var sinceT, untilT time.Time
+	if since != "" {
+		sinceT, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			log.Fatalf("invalid --since: %v", err)
+		}
+	}
+	if until != "" {
+		untilT, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			log.Fatalf("invalid --until: %v", err)
+		}
+	}
+
+	replayed := 0
+	for _, record := range records {
+		if !sinceT.IsZero() && record.ReceivedAt.Before(sinceT) {
+			continue
+		}
+		if !untilT.IsZero() && !record.ReceivedAt.Before(untilT) {
+			continue
+		}
+
+		var alertMessage AlertMessage
+		if err := json.Unmarshal(record.Payload, &alertMessage); err != nil {
+			log.Printf("error decoding alert %s, skipping: %v", record.ID, err)
+			continue
+		}
+
+		if err := processAlertMessage(context.Background(), alertMessage); err != nil {
+			log.Printf("error replaying alert %s: %v", record.ID, err)
+			_ = alertStore.MarkFailed(record.ID, err)
+			continue
+		}
+
+		_ = alertStore.MarkSent(record.ID)
+		replayed++
+	}
+
+	log.Printf("replayed %d/%d alerts", replayed, len(records))
+}
+
+var (
+	replaySince string
+	replayUntil string
+	replayAll   bool
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "alerthandler",
+	Short: "Relays Alertmanager webhooks to Grafana-snapshotted notifications",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return setup(cmd.Name() == "serve")
+	},
+}
+
+// setup validates configuration and wires up every package-level dependency
+// once flags have been parsed. It runs via rootCmd.PersistentPreRunE rather
+// than an init(), and returns an aggregated error instead of calling
+// log.Fatalf so every problem is reported together. runDiagnosticChecks
+// gates the Grafana/SMTP reachability probes: they hard-fail setup when a
+// dependency is unreachable, which is the opposite of what replay needs
+// when it's recovering from exactly that kind of outage, so only "serve"
+// runs them by default (cfg.SkipDiagnostics can also force them off).
+func setup(runDiagnosticChecks bool) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	var err error
+	notifyCfg, err = notify.LoadConfig(cfg.NotifyConfig)
+	if err != nil {
+		return fmt.Errorf("error loading notify config: %w", err)
+	}
+	notifiers, err = notify.Build(notifyCfg)
+	if err != nil {
+		return fmt.Errorf("error building notifiers: %w", err)
+	}
+
+	dashCache = newDashboardCache(cfg.DashboardCacheTTL)
+
+	subjectTmpl, err = loadTemplate("subject", cfg.SubjectTemplate, defaultSubjectTemplate)
+	if err != nil {
+		return err
+	}
+	bodyTmpl, err = loadTemplate("body", cfg.BodyTemplate, defaultBodyTemplate)
+	if err != nil {
+		return err
+	}
+
+	alertStore, err = store.Open(cfg.QueueDBPath)
+	if err != nil {
+		return fmt.Errorf("error opening alert queue: %w", err)
+	}
+
+	if runDiagnosticChecks && !cfg.SkipDiagnostics {
+		if err := runDiagnostics(); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Configuration valid. GRAFANA_URL=%s, DASHBOARD_UID=%s, NOTIFY_CONFIG=%s",
+		cfg.GrafanaURL, cfg.DashboardUID, cfg.NotifyConfig)
+	return nil
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the alert webhook HTTP server",
+	Run: func(cmd *cobra.Command, args []string) {
+		runServer()
+	},
+}
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Re-process unacked or time-range-filtered alerts from the queue",
+	Run: func(cmd *cobra.Command, args []string) {
+		runReplay(replaySince, replayUntil, replayAll)
+	},
+}
+
+func init() {
+	registerConfigFlags(rootCmd.PersistentFlags())
+
+	replayCmd.Flags().StringVar(&replaySince, "since", "", "only replay alerts received at or after this RFC3339 timestamp")
+	replayCmd.Flags().StringVar(&replayUntil, "until", "", "only replay alerts received before this RFC3339 timestamp")
+	replayCmd.Flags().BoolVar(&replayAll, "all", false, "replay every stored alert, not just unacked ones")
+
+	rootCmd.AddCommand(serveCmd, replayCmd)
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+	if alertStore != nil {
+		alertStore.Close()
+	}
+}
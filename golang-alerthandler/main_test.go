@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDashboardCacheGetSet(t *testing.T) {
+	t.Run("miss before anything is cached", func(t *testing.T) {
+		c := newDashboardCache(time.Minute)
+		if _, ok := c.get("uid-1"); ok {
+			t.Fatal("get() = hit, want miss on empty cache")
+		}
+	})
+
+	t.Run("hit when fresh within TTL", func(t *testing.T) {
+		c := newDashboardCache(time.Minute)
+		c.checkFresh = func(dashboardUID, etag string) (bool, error) { return true, nil }
+		want := map[string]interface{}{"version": float64(1)}
+		c.set("uid-1", want, "etag-1")
+
+		got, ok := c.get("uid-1")
+		if !ok {
+			t.Fatal("get() = miss, want hit")
+		}
+		if got["version"] != want["version"] {
+			t.Errorf("get() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("miss once TTL has elapsed, without calling checkFresh", func(t *testing.T) {
+		c := newDashboardCache(time.Millisecond)
+		calls := 0
+		c.checkFresh = func(dashboardUID, etag string) (bool, error) { calls++; return true, nil }
+		c.set("uid-1", map[string]interface{}{}, "etag-1")
+
+		time.Sleep(5 * time.Millisecond)
+		if _, ok := c.get("uid-1"); ok {
+			t.Fatal("get() = hit, want miss after TTL elapsed")
+		}
+		if calls != 0 {
+			t.Errorf("checkFresh called %d times, want 0 (TTL should short-circuit before the network check)", calls)
+		}
+	})
+
+	t.Run("miss when Grafana reports the dashboard changed", func(t *testing.T) {
+		c := newDashboardCache(time.Minute)
+		c.checkFresh = func(dashboardUID, etag string) (bool, error) { return false, nil }
+		c.set("uid-1", map[string]interface{}{}, "etag-1")
+
+		if _, ok := c.get("uid-1"); ok {
+			t.Fatal("get() = hit, want miss when checkFresh reports stale")
+		}
+	})
+
+	t.Run("miss, not error, when the freshness check fails", func(t *testing.T) {
+		c := newDashboardCache(time.Minute)
+		c.checkFresh = func(dashboardUID, etag string) (bool, error) { return false, errTestNetwork }
+		c.set("uid-1", map[string]interface{}{}, "etag-1")
+
+		if _, ok := c.get("uid-1"); ok {
+			t.Fatal("get() = hit, want miss when checkFresh errors")
+		}
+	})
+}
+
+func TestRenderPanelPNGEscapesTheme(t *testing.T) {
+	var gotQuery string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("fakepng"))
+	}))
+	defer server.Close()
+
+	origCfg := cfg
+	defer func() { cfg = origCfg }()
+	cfg.GrafanaURL = server.URL
+	cfg.GrafanaAPIKey = "test-key"
+	cfg.RenderWidth = 1000
+	cfg.RenderHeight = 500
+
+	from := time.Unix(0, 0)
+	to := time.Unix(100, 0)
+
+	// A theme containing '&' would inject its own parameters (here a second
+	// "from") into the query string if spliced in with fmt.Sprintf instead
+	// of url.Values.
+	png, err := renderPanelPNG("dash-1", 42, from, to, "dark&from=bogus")
+	if err != nil {
+		t.Fatalf("renderPanelPNG() error = %v", err)
+	}
+	if string(png) != "fakepng" {
+		t.Errorf("png = %q, want %q", png, "fakepng")
+	}
+
+	values, err := url.ParseQuery(gotQuery)
+	if err != nil {
+		t.Fatalf("url.ParseQuery(%q) error = %v", gotQuery, err)
+	}
+	if got := values.Get("theme"); got != "dark&from=bogus" {
+		t.Errorf("theme = %q, want %q", got, "dark&from=bogus")
+	}
+	if got := values.Get("panelId"); got != "42" {
+		t.Errorf("panelId = %q, want %q", got, "42")
+	}
+	if got := values.Get("from"); got != "0" {
+		t.Errorf("from = %q, want %q (the real render window, not overridden by the theme value)", got, "0")
+	}
+}
+
+var errTestNetwork = &testNetworkError{}
+
+type testNetworkError struct{}
+
+func (e *testNetworkError) Error() string { return "simulated network error" }
@@ -0,0 +1,122 @@
+package notify
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RouteConfig selects which notifiers handle an alert based on label
+// matches. An alert matches a route when every key/value pair in Match
+// equals the alert's label of the same name. A route with no Match entries
+// matches everything, so it should be listed last as the default.
+type RouteConfig struct {
+	Match     map[string]string `yaml:"match"`
+	Notifiers []string          `yaml:"notifiers"`
+}
+
+// NotifierConfig describes one configured backend. Only the fields relevant
+// to Type need to be set.
+type NotifierConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"` // smtp, slack, pagerduty, mailgun
+
+	// smtp
+	SMTPServer     string `yaml:"smtp_server"`
+	SMTPPort       int    `yaml:"smtp_port"`
+	SenderEmail    string `yaml:"sender_email"`
+	SenderPassword string `yaml:"sender_password"`
+	RecipientEmail string `yaml:"recipient_email"`
+
+	// slack
+	WebhookURL string `yaml:"webhook_url"`
+
+	// pagerduty
+	RoutingKey string `yaml:"routing_key"`
+
+	// mailgun
+	Domain string `yaml:"domain"`
+	APIKey string `yaml:"api_key"`
+	From   string `yaml:"from"`
+	To     string `yaml:"to"`
+}
+
+// Config is the top-level notify configuration, loaded from a YAML or JSON
+// file at startup.
+type Config struct {
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+	Routes    []RouteConfig    `yaml:"routes"`
+}
+
+// LoadConfig reads and parses the notify config file. JSON is valid YAML,
+// so the same loader handles both.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading notify config %s: %w", path, err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing notify config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// Build constructs a Notifier for every configured backend, keyed by name.
+func Build(cfg *Config) (map[string]Notifier, error) {
+	notifiers := make(map[string]Notifier, len(cfg.Notifiers))
+	for _, nc := range cfg.Notifiers {
+		notifier, err := build(nc)
+		if err != nil {
+			return nil, fmt.Errorf("error building notifier %q: %w", nc.Name, err)
+		}
+		notifiers[nc.Name] = notifier
+	}
+	return notifiers, nil
+}
+
+func build(nc NotifierConfig) (Notifier, error) {
+	switch nc.Type {
+	case "smtp":
+		return NewSMTPNotifier(nc.Name, nc.SMTPServer, nc.SMTPPort, nc.SenderEmail, nc.SenderPassword, nc.RecipientEmail), nil
+	case "slack":
+		return NewSlackNotifier(nc.Name, nc.WebhookURL), nil
+	case "pagerduty":
+		return NewPagerDutyNotifier(nc.Name, nc.RoutingKey), nil
+	case "mailgun":
+		return NewMailgunNotifier(nc.Name, nc.Domain, nc.APIKey, nc.From, nc.To), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", nc.Type)
+	}
+}
+
+// Select returns the notifiers assigned to the first route whose Match
+// criteria are satisfied by labels, in the order the route lists them.
+func (c *Config) Select(notifiers map[string]Notifier, labels map[string]string) []Notifier {
+	for _, route := range c.Routes {
+		if !matches(route.Match, labels) {
+			continue
+		}
+
+		var selected []Notifier
+		for _, name := range route.Notifiers {
+			if n, ok := notifiers[name]; ok {
+				selected = append(selected, n)
+			}
+		}
+		return selected
+	}
+	return nil
+}
+
+func matches(match, labels map[string]string) bool {
+	for k, v := range match {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		match  map[string]string
+		labels map[string]string
+		want   bool
+	}{
+		{
+			name:   "empty match matches everything",
+			match:  nil,
+			labels: map[string]string{"severity": "critical"},
+			want:   true,
+		},
+		{
+			name:   "single key matches",
+			match:  map[string]string{"severity": "critical"},
+			labels: map[string]string{"severity": "critical", "team": "infra"},
+			want:   true,
+		},
+		{
+			name:   "single key mismatches",
+			match:  map[string]string{"severity": "critical"},
+			labels: map[string]string{"severity": "warning"},
+			want:   false,
+		},
+		{
+			name:   "missing label fails",
+			match:  map[string]string{"severity": "critical"},
+			labels: map[string]string{"team": "infra"},
+			want:   false,
+		},
+		{
+			name:   "every key must match",
+			match:  map[string]string{"severity": "critical", "team": "infra"},
+			labels: map[string]string{"severity": "critical", "team": "payments"},
+			want:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(tt.match, tt.labels); got != tt.want {
+				t.Errorf("matches(%v, %v) = %v, want %v", tt.match, tt.labels, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigSelect(t *testing.T) {
+	slack := NewSlackNotifier("slack", "https://hooks.example/slack")
+	pager := NewPagerDutyNotifier("pagerduty", "routing-key")
+	notifiers := map[string]Notifier{
+		"slack":     slack,
+		"pagerduty": pager,
+	}
+
+	cfg := &Config{
+		Routes: []RouteConfig{
+			{Match: map[string]string{"severity": "critical"}, Notifiers: []string{"pagerduty", "slack"}},
+			{Match: nil, Notifiers: []string{"slack"}},
+		},
+	}
+
+	t.Run("matches first route in order", func(t *testing.T) {
+		got := cfg.Select(notifiers, map[string]string{"severity": "critical"})
+		want := []Notifier{pager, slack}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Select() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("falls through to default route", func(t *testing.T) {
+		got := cfg.Select(notifiers, map[string]string{"severity": "warning"})
+		want := []Notifier{slack}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Select() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("unknown notifier name in route is skipped", func(t *testing.T) {
+		cfg := &Config{
+			Routes: []RouteConfig{
+				{Match: nil, Notifiers: []string{"missing", "slack"}},
+			},
+		}
+		got := cfg.Select(notifiers, map[string]string{})
+		want := []Notifier{slack}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Select() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("no route matches returns nil", func(t *testing.T) {
+		cfg := &Config{
+			Routes: []RouteConfig{
+				{Match: map[string]string{"severity": "critical"}, Notifiers: []string{"slack"}},
+			},
+		}
+		got := cfg.Select(notifiers, map[string]string{"severity": "warning"})
+		if got != nil {
+			t.Errorf("Select() = %v, want nil", got)
+		}
+	})
+}
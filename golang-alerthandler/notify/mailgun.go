@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const mailgunBaseURL = "https://api.mailgun.net"
+
+// MailgunNotifier sends alerts as HTML email through the Mailgun HTTP API.
+type MailgunNotifier struct {
+	name    string
+	domain  string
+	apiKey  string
+	from    string
+	to      string
+	baseURL string // overridden in tests; defaults to mailgunBaseURL
+	client  *http.Client
+}
+
+func NewMailgunNotifier(name, domain, apiKey, from, to string) *MailgunNotifier {
+	return &MailgunNotifier{name: name, domain: domain, apiKey: apiKey, from: from, to: to, baseURL: mailgunBaseURL, client: &http.Client{}}
+}
+
+func (m *MailgunNotifier) Name() string { return m.name }
+
+func (m *MailgunNotifier) Notify(ctx context.Context, alert Alert, subject, body string, attachments []Attachment) error {
+	endpoint := fmt.Sprintf("%s/v3/%s/messages", m.baseURL, m.domain)
+
+	form := url.Values{}
+	form.Set("from", m.from)
+	form.Set("to", m.to)
+	form.Set("subject", subject)
+	form.Set("html", body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("error creating mailgun request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("api", m.apiKey)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending mailgun request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected mailgun status code: %d", resp.StatusCode)
+	}
+	return nil
+}
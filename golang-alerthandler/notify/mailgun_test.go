@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func newTestMailgunNotifier(server *httptest.Server) *MailgunNotifier {
+	n := NewMailgunNotifier("mailgun", "mg.example.com", "key-123", "alerts@example.com", "oncall@example.com")
+	n.baseURL = server.URL
+	return n
+}
+
+func TestMailgunNotifierNotify(t *testing.T) {
+	var gotPath, gotUser, gotPass, gotContentType string
+	var gotForm url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotContentType = r.Header.Get("Content-Type")
+		gotUser, gotPass, _ = r.BasicAuth()
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("ParseForm() error = %v", err)
+		}
+		gotForm = r.PostForm
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := newTestMailgunNotifier(server)
+	if err := n.Notify(context.Background(), Alert{Status: "firing"}, "subject line", "<p>body</p>", nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if gotPath != "/v3/mg.example.com/messages" {
+		t.Errorf("path = %q, want %q", gotPath, "/v3/mg.example.com/messages")
+	}
+	if gotContentType != "application/x-www-form-urlencoded" {
+		t.Errorf("Content-Type = %q, want application/x-www-form-urlencoded", gotContentType)
+	}
+	if gotUser != "api" || gotPass != "key-123" {
+		t.Errorf("basic auth = (%q, %q), want (%q, %q)", gotUser, gotPass, "api", "key-123")
+	}
+	if got := gotForm.Get("from"); got != "alerts@example.com" {
+		t.Errorf("from = %q, want %q", got, "alerts@example.com")
+	}
+	if got := gotForm.Get("to"); got != "oncall@example.com" {
+		t.Errorf("to = %q, want %q", got, "oncall@example.com")
+	}
+	if got := gotForm.Get("subject"); got != "subject line" {
+		t.Errorf("subject = %q, want %q", got, "subject line")
+	}
+	if got := gotForm.Get("html"); got != "<p>body</p>" {
+		t.Errorf("html = %q, want %q", got, "<p>body</p>")
+	}
+}
+
+func TestMailgunNotifierErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	n := newTestMailgunNotifier(server)
+	if err := n.Notify(context.Background(), Alert{Status: "firing"}, "subject", "body", nil); err == nil {
+		t.Error("Notify() error = nil, want an error on a non-200 response")
+	}
+}
@@ -0,0 +1,73 @@
+// Package notify defines the pluggable notifier subsystem used to deliver
+// alerts through one or more backends (SMTP, Slack, PagerDuty, Mailgun).
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert is the subset of alert data a notifier needs to render a message.
+// It represents a digest of a whole Alertmanager notification group rather
+// than a single firing alert.
+type Alert struct {
+	Status       string
+	Labels       map[string]string
+	Annotations  map[string]string
+	GroupKey     string
+	Fingerprints []string // per-alert fingerprints of every alert in the group, sorted
+}
+
+// Attachment is a binary attachment, such as a rendered Grafana panel, that
+// a notifier may include with its message.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Notifier delivers an alert through a single backend.
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, alert Alert, subject, body string, attachments []Attachment) error
+}
+
+// Dispatch sends the alert through every notifier concurrently, bounding
+// each by its own timeout, and returns an aggregate error describing every
+// notifier that failed.
+func Dispatch(ctx context.Context, notifiers []Notifier, timeout time.Duration, alert Alert, subject, body string, attachments []Attachment) error {
+	if len(notifiers) == 0 {
+		return nil
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []string
+	)
+
+	for _, n := range notifiers {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+
+			nCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			if err := n.Notify(nCtx, alert, subject, body, attachments); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Sprintf("%s: %v", n.Name(), err))
+				mu.Unlock()
+			}
+		}(n)
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("notifier errors: %s", strings.Join(errs, "; "))
+}
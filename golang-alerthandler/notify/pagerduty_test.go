@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestPagerDutyNotifier(server *httptest.Server) *PagerDutyNotifier {
+	n := NewPagerDutyNotifier("pagerduty", "routing-key")
+	n.eventsURL = server.URL
+	return n
+}
+
+func TestPagerDutyNotifierTrigger(t *testing.T) {
+	var got pagerDutyEvent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := newTestPagerDutyNotifier(server)
+	alert := Alert{Status: "firing", GroupKey: "group-1", Labels: map[string]string{"severity": "warning"}}
+
+	if err := n.Notify(context.Background(), alert, "subject", "body", nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if got.EventAction != "trigger" {
+		t.Errorf("EventAction = %q, want %q", got.EventAction, "trigger")
+	}
+	if got.DedupKey != "group-1" {
+		t.Errorf("DedupKey = %q, want %q", got.DedupKey, "group-1")
+	}
+	if got.Payload == nil {
+		t.Fatal("Payload = nil, want it set for a firing alert")
+	}
+	if got.Payload.Severity != "warning" {
+		t.Errorf("Severity = %q, want %q", got.Payload.Severity, "warning")
+	}
+	if got.Payload.Summary != "subject" {
+		t.Errorf("Summary = %q, want %q", got.Payload.Summary, "subject")
+	}
+}
+
+func TestPagerDutyNotifierTriggerDefaultSeverity(t *testing.T) {
+	var got pagerDutyEvent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := newTestPagerDutyNotifier(server)
+	alert := Alert{Status: "firing", GroupKey: "group-1"}
+
+	if err := n.Notify(context.Background(), alert, "subject", "body", nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if got.Payload.Severity != "critical" {
+		t.Errorf("Severity = %q, want default %q", got.Payload.Severity, "critical")
+	}
+}
+
+func TestPagerDutyNotifierResolve(t *testing.T) {
+	var got pagerDutyEvent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := newTestPagerDutyNotifier(server)
+	alert := Alert{Status: "resolved", GroupKey: "group-1"}
+
+	if err := n.Notify(context.Background(), alert, "subject", "body", nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if got.EventAction != "resolve" {
+		t.Errorf("EventAction = %q, want %q", got.EventAction, "resolve")
+	}
+	if got.Payload != nil {
+		t.Errorf("Payload = %v, want nil for a resolve event", got.Payload)
+	}
+}
+
+func TestPagerDutyNotifierDedupFallback(t *testing.T) {
+	var got pagerDutyEvent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	n := newTestPagerDutyNotifier(server)
+	alert := Alert{
+		Status:       "firing",
+		Labels:       map[string]string{"alertname": "HighCPU"},
+		Fingerprints: []string{"abc123", "def456"},
+	}
+
+	if err := n.Notify(context.Background(), alert, "subject", "body", nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	want := "HighCPU" + "abc123,def456"
+	if got.DedupKey != want {
+		t.Errorf("DedupKey = %q, want %q", got.DedupKey, want)
+	}
+}
+
+func TestPagerDutyNotifierErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	n := newTestPagerDutyNotifier(server)
+	if err := n.Notify(context.Background(), Alert{Status: "firing"}, "subject", "body", nil); err == nil {
+		t.Error("Notify() error = nil, want an error on a non-202 response")
+	}
+}
@@ -0,0 +1,74 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SlackNotifier posts alerts to a Slack incoming webhook as a message
+// attachment. Slack webhooks cannot carry binary payloads, so any rendered
+// panel images are referenced by name rather than embedded.
+type SlackNotifier struct {
+	name       string
+	webhookURL string
+	client     *http.Client
+}
+
+func NewSlackNotifier(name, webhookURL string) *SlackNotifier {
+	return &SlackNotifier{name: name, webhookURL: webhookURL, client: &http.Client{}}
+}
+
+func (s *SlackNotifier) Name() string { return s.name }
+
+type slackPayload struct {
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+type slackAttachment struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	Color string `json:"color"`
+}
+
+func (s *SlackNotifier) Notify(ctx context.Context, alert Alert, subject, body string, attachments []Attachment) error {
+	color := "warning"
+	if alert.Status == "resolved" {
+		color = "good"
+	}
+
+	text := body
+	for _, a := range attachments {
+		text += fmt.Sprintf("\nsnapshot: %s", a.Name)
+	}
+
+	payload := slackPayload{
+		Attachments: []slackAttachment{
+			{Title: subject, Text: text, Color: color},
+		},
+	}
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error marshalling slack payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.webhookURL, bytes.NewReader(jsonPayload))
+	if err != nil {
+		return fmt.Errorf("error creating slack request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending slack request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected slack status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlackNotifierNotify(t *testing.T) {
+	var gotPayload slackPayload
+	var gotMethod, gotContentType string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotContentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&gotPayload); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier("slack", server.URL)
+	alert := Alert{Status: "firing"}
+	attachments := []Attachment{{Name: "panel-1.png", ContentType: "image/png", Data: []byte("png")}}
+
+	if err := n.Notify(context.Background(), alert, "alert subject", "alert body", attachments); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPost {
+		t.Errorf("method = %q, want POST", gotMethod)
+	}
+	if gotContentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", gotContentType)
+	}
+	if len(gotPayload.Attachments) != 1 {
+		t.Fatalf("Attachments = %v, want 1 entry", gotPayload.Attachments)
+	}
+	got := gotPayload.Attachments[0]
+	if got.Title != "alert subject" {
+		t.Errorf("Title = %q, want %q", got.Title, "alert subject")
+	}
+	if got.Color != "warning" {
+		t.Errorf("Color = %q, want %q for a firing alert", got.Color, "warning")
+	}
+	if !strings.Contains(got.Text, "alert body") || !strings.Contains(got.Text, "panel-1.png") {
+		t.Errorf("Text = %q, want it to contain the body and attachment name", got.Text)
+	}
+}
+
+func TestSlackNotifierNotifyResolved(t *testing.T) {
+	var gotPayload slackPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotPayload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier("slack", server.URL)
+	if err := n.Notify(context.Background(), Alert{Status: "resolved"}, "subject", "body", nil); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if got := gotPayload.Attachments[0].Color; got != "good" {
+		t.Errorf("Color = %q, want %q for a resolved alert", got, "good")
+	}
+}
+
+func TestSlackNotifierNotifyErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	n := NewSlackNotifier("slack", server.URL)
+	if err := n.Notify(context.Background(), Alert{Status: "firing"}, "subject", "body", nil); err == nil {
+		t.Error("Notify() error = nil, want an error on a non-200 response")
+	}
+}
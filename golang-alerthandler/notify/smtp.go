@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"gopkg.in/gomail.v2"
+)
+
+// SMTPNotifier delivers alerts as HTML email via gomail, embedding any
+// attachments inline using their name as the Content-ID.
+type SMTPNotifier struct {
+	name           string
+	server         string
+	port           int
+	senderEmail    string
+	senderPassword string
+	recipientEmail string
+}
+
+func NewSMTPNotifier(name, server string, port int, senderEmail, senderPassword, recipientEmail string) *SMTPNotifier {
+	return &SMTPNotifier{
+		name:           name,
+		server:         server,
+		port:           port,
+		senderEmail:    senderEmail,
+		senderPassword: senderPassword,
+		recipientEmail: recipientEmail,
+	}
+}
+
+func (s *SMTPNotifier) Name() string { return s.name }
+
+func (s *SMTPNotifier) Notify(ctx context.Context, alert Alert, subject, body string, attachments []Attachment) error {
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.senderEmail)
+	m.SetHeader("To", s.recipientEmail)
+	m.SetHeader("Subject", subject)
+
+	htmlBody := body
+	for _, a := range attachments {
+		htmlBody += fmt.Sprintf("<br><img src='cid:%s' alt='%s'>", a.Name, a.Name)
+	}
+	m.SetBody("text/html", htmlBody)
+
+	for _, a := range attachments {
+		data := a.Data
+		m.Embed(a.Name, gomail.SetCopyFunc(func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}))
+	}
+
+	d := gomail.NewDialer(s.server, s.port, s.senderEmail, s.senderPassword)
+	if err := d.DialAndSend(m); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
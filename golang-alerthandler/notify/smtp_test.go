@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeSMTPServer accepts a single SMTP connection, replies just enough to
+// satisfy gomail's dialer (no STARTTLS/AUTH advertised, so the client skips
+// both), and returns the DATA section it received.
+func fakeSMTPServer(t *testing.T) (addr string, received <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		writeLine := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+		writeLine("220 localhost SMTP fake")
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+
+			if inData {
+				if line == "." {
+					inData = false
+					writeLine("250 OK")
+					continue
+				}
+				data.WriteString(line + "\n")
+				continue
+			}
+
+			switch {
+			case strings.HasPrefix(strings.ToUpper(line), "EHLO"), strings.HasPrefix(strings.ToUpper(line), "HELO"):
+				writeLine("250 localhost")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM"):
+				writeLine("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO"):
+				writeLine("250 OK")
+			case strings.ToUpper(line) == "DATA":
+				inData = true
+				writeLine("354 Go ahead")
+			case strings.ToUpper(line) == "QUIT":
+				writeLine("221 Bye")
+				out <- data.String()
+				return
+			default:
+				writeLine("500 unrecognized command")
+			}
+		}
+	}()
+
+	return ln.Addr().String(), out
+}
+
+func TestSMTPNotifierNotify(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatalf("net.SplitHostPort() error = %v", err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		t.Fatalf("parsing port %q: %v", portStr, err)
+	}
+
+	n := NewSMTPNotifier("smtp", host, port, "alerts@example.com", "", "oncall@example.com")
+
+	err = n.Notify(context.Background(), Alert{Status: "firing"}, "subject line", "<p>body</p>", nil)
+	if err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	data := <-received
+	if !strings.Contains(data, "subject line") {
+		t.Errorf("DATA = %q, want it to contain the subject", data)
+	}
+	if !strings.Contains(data, "<p>body</p>") {
+		t.Errorf("DATA = %q, want it to contain the body", data)
+	}
+}
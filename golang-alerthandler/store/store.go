@@ -0,0 +1,172 @@
+// Package store persists incoming alert payloads in BoltDB so they can be
+// replayed after an SMTP outage or Grafana downtime.
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var alertsBucket = []byte("alerts")
+
+// Status values for a stored alert record.
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Record is one persisted webhook delivery.
+type Record struct {
+	ID           string    `json:"id"`
+	Payload      []byte    `json:"payload"`
+	Status       string    `json:"status"`
+	ReceivedAt   time.Time `json:"receivedAt"`
+	DispatchedAt time.Time `json:"dispatchedAt,omitempty"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// Store is a BoltDB-backed queue of alert deliveries.
+type Store struct {
+	db *bbolt.DB
+}
+
+// Open opens (creating if necessary) the BoltDB file at path.
+func Open(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening alert store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(alertsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error initializing alert store: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Enqueue persists a raw alert payload before it's dispatched, returning its
+// generated ID.
+func (s *Store) Enqueue(payload []byte) (string, error) {
+	record := Record{
+		Payload:    payload,
+		Status:     StatusPending,
+		ReceivedAt: time.Now(),
+	}
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(alertsBucket)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		record.ID = fmt.Sprintf("%020d", seq)
+
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(record.ID), data)
+	})
+	if err != nil {
+		return "", fmt.Errorf("error enqueuing alert: %w", err)
+	}
+
+	return record.ID, nil
+}
+
+func (s *Store) updateStatus(id, status, errMsg string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(alertsBucket)
+		data := bucket.Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("alert %s not found", id)
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+
+		record.Status = status
+		record.DispatchedAt = time.Now()
+		record.Error = errMsg
+
+		updated, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(id), updated)
+	})
+}
+
+// MarkSent records a successful dispatch.
+func (s *Store) MarkSent(id string) error {
+	return s.updateStatus(id, StatusSent, "")
+}
+
+// MarkFailed records a failed dispatch along with the cause.
+func (s *Store) MarkFailed(id string, cause error) error {
+	return s.updateStatus(id, StatusFailed, cause.Error())
+}
+
+// Get returns a single record by ID.
+func (s *Store) Get(id string) (Record, error) {
+	var record Record
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(alertsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("alert %s not found", id)
+		}
+		return json.Unmarshal(data, &record)
+	})
+	return record, err
+}
+
+// List returns the most recent records, newest first. A limit of 0 returns
+// every record.
+func (s *Store) List(limit int) ([]Record, error) {
+	var records []Record
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(alertsBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			var record Record
+			if err := json.Unmarshal(v, &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+			if limit > 0 && len(records) >= limit {
+				break
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Unacked returns every record that hasn't been successfully sent.
+func (s *Store) Unacked() ([]Record, error) {
+	all, err := s.List(0)
+	if err != nil {
+		return nil, err
+	}
+
+	var unacked []Record
+	for _, record := range all {
+		if record.Status != StatusSent {
+			unacked = append(unacked, record)
+		}
+	}
+	return unacked, nil
+}
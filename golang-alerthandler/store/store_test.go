@@ -0,0 +1,161 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "alerts.db"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestEnqueueMarkSent(t *testing.T) {
+	s := openTestStore(t)
+
+	id, err := s.Enqueue([]byte(`{"status":"firing"}`))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	record, err := s.Get(id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if record.Status != StatusPending {
+		t.Errorf("Status = %q, want %q", record.Status, StatusPending)
+	}
+
+	if err := s.MarkSent(id); err != nil {
+		t.Fatalf("MarkSent() error = %v", err)
+	}
+
+	record, err = s.Get(id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if record.Status != StatusSent {
+		t.Errorf("Status = %q, want %q", record.Status, StatusSent)
+	}
+	if record.DispatchedAt.IsZero() {
+		t.Error("DispatchedAt is zero, want it set after MarkSent")
+	}
+	if record.Error != "" {
+		t.Errorf("Error = %q, want empty", record.Error)
+	}
+}
+
+func TestEnqueueMarkFailed(t *testing.T) {
+	s := openTestStore(t)
+
+	id, err := s.Enqueue([]byte(`{"status":"firing"}`))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	cause := errors.New("smtp dial timeout")
+	if err := s.MarkFailed(id, cause); err != nil {
+		t.Fatalf("MarkFailed() error = %v", err)
+	}
+
+	record, err := s.Get(id)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if record.Status != StatusFailed {
+		t.Errorf("Status = %q, want %q", record.Status, StatusFailed)
+	}
+	if record.Error != cause.Error() {
+		t.Errorf("Error = %q, want %q", record.Error, cause.Error())
+	}
+}
+
+func TestGetMissing(t *testing.T) {
+	s := openTestStore(t)
+
+	if _, err := s.Get("00000000000000000001"); err == nil {
+		t.Error("Get() error = nil, want not-found error")
+	}
+}
+
+func TestListOrderAndLimit(t *testing.T) {
+	s := openTestStore(t)
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		id, err := s.Enqueue([]byte(`{}`))
+		if err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	all, err := s.List(0)
+	if err != nil {
+		t.Fatalf("List(0) error = %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("List(0) returned %d records, want 3", len(all))
+	}
+	// newest first
+	if all[0].ID != ids[2] || all[1].ID != ids[1] || all[2].ID != ids[0] {
+		t.Errorf("List(0) order = %v, want newest-first %v", []string{all[0].ID, all[1].ID, all[2].ID}, ids)
+	}
+
+	limited, err := s.List(2)
+	if err != nil {
+		t.Fatalf("List(2) error = %v", err)
+	}
+	if len(limited) != 2 {
+		t.Errorf("List(2) returned %d records, want 2", len(limited))
+	}
+}
+
+func TestUnacked(t *testing.T) {
+	s := openTestStore(t)
+
+	pendingID, err := s.Enqueue([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	sentID, err := s.Enqueue([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	failedID, err := s.Enqueue([]byte(`{}`))
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	if err := s.MarkSent(sentID); err != nil {
+		t.Fatalf("MarkSent() error = %v", err)
+	}
+	if err := s.MarkFailed(failedID, errors.New("boom")); err != nil {
+		t.Fatalf("MarkFailed() error = %v", err)
+	}
+
+	unacked, err := s.Unacked()
+	if err != nil {
+		t.Fatalf("Unacked() error = %v", err)
+	}
+
+	got := make(map[string]bool, len(unacked))
+	for _, r := range unacked {
+		got[r.ID] = true
+	}
+	if !got[pendingID] || !got[failedID] {
+		t.Errorf("Unacked() = %v, want it to include pending %q and failed %q", got, pendingID, failedID)
+	}
+	if got[sentID] {
+		t.Errorf("Unacked() includes sent record %q, want it excluded", sentID)
+	}
+	if len(unacked) != 2 {
+		t.Errorf("Unacked() returned %d records, want 2", len(unacked))
+	}
+}